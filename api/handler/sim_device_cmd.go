@@ -1,6 +1,11 @@
 package handler
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -8,48 +13,98 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/sshcollectorpro/sshcollectorpro/internal/database"
+	simenc "github.com/sshcollectorpro/sshcollectorpro/internal/encoding"
 	"github.com/sshcollectorpro/sshcollectorpro/internal/model"
+	"github.com/sshcollectorpro/sshcollectorpro/internal/simtrie"
 	"github.com/sshcollectorpro/sshcollectorpro/pkg/logger"
+	"gopkg.in/yaml.v3"
 	"gorm.io/gorm"
 )
 
-// SimDeviceCmdHandler 针对命名空间与设备的模拟命令处理器
-// 路由建议：/api/v1/sim-device-cmds
-// 支持：查询（按namespace、device_name、enabled）、创建、查看、更新、删除
+// 支持的批量导入/导出格式
+const (
+	bundleFormatYAML = "yaml"
+	bundleFormatJSON = "json"
+	bundleFormatCSV  = "csv"
+)
 
-type SimDeviceCmdHandler struct{}
+// 导入冲突策略
+const (
+	conflictPolicySkip        = "skip"
+	conflictPolicyOverwrite   = "overwrite"
+	conflictPolicyMergeOutput = "merge-output"
+)
 
-func NewSimDeviceCmdHandler() *SimDeviceCmdHandler { return &SimDeviceCmdHandler{} }
+// simDeviceCmdBundleRow 导入/导出时使用的行结构，额外带 csv/yaml 标签
+type simDeviceCmdBundleRow struct {
+	Namespace  string `json:"namespace" yaml:"namespace" csv:"namespace"`
+	DeviceName string `json:"device_name" yaml:"device_name" csv:"device_name"`
+	Command    string `json:"command" yaml:"command" csv:"command"`
+	Output     string `json:"output" yaml:"output" csv:"output"`
+	Enabled    bool   `json:"enabled" yaml:"enabled" csv:"enabled"`
+	// Encoding 声明该行 command/output 的原始字符集，覆盖请求级别的 encoding 参数；留空则沿用请求级别设置
+	Encoding string `json:"encoding,omitempty" yaml:"encoding,omitempty" csv:"encoding"`
+}
+
+// simDeviceCmdImportRowResult 单行导入结果
+type simDeviceCmdImportRowResult struct {
+	Row     int    `json:"row"`
+	Action  string `json:"action"` // created / updated / skipped / failed
+	Command string `json:"command,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
 
-// 辅助：规范化命令文本（压缩空白并小写化，仅用于匹配，不更改存储原文）
-func normalizeCommand(s string) string {
-	s = strings.TrimSpace(s)
-	if s == "" { return s }
-	s = strings.ReplaceAll(s, "\r\n", "\n")
-	s = strings.ReplaceAll(s, "\t", " ")
-	s = strings.Join(strings.Fields(s), " ")
-	return strings.ToLower(s)
+// simDeviceCmdUpsertRequest 创建/更新请求体，在模型字段之外附带原始抓取编码
+type simDeviceCmdUpsertRequest struct {
+	model.SimDeviceCommand
+	// Encoding 声明 command/output 的原始字符集（如 gbk、gb18030、big5、iso-8859-1、shift_jis），
+	// 为空或 utf-8 时不转码；非 utf-8 时转码后写入 Command/Output，原始字节保留在 OutputRaw
+	Encoding string `json:"encoding,omitempty"`
 }
 
-// 辅助：按位置进行前缀匹配（每个输入词必须匹配候选命令对应位置词的前缀）
-func prefixMatchByWords(input string, candidate string) bool {
-	in := strings.Fields(normalizeCommand(input))
-	cand := strings.Fields(normalizeCommand(candidate))
-	if len(in) == 0 { return false }
-	if len(cand) < len(in) { return false }
-	for i := 0; i < len(in); i++ {
-		if !strings.HasPrefix(cand[i], in[i]) { return false }
+// applyRecordEncoding 按声明字符集将命令/回显转码为 UTF-8，保留转码前的原始字节
+func applyRecordEncoding(enc string, rec *model.SimDeviceCommand) error {
+	enc = strings.ToLower(strings.TrimSpace(enc))
+	if enc == "" || enc == "utf-8" || enc == "utf8" {
+		return nil
 	}
-	return true
+	rawOutput := []byte(rec.Output)
+	convertedOutput, err := simenc.ToUTF8(enc, rawOutput)
+	if err != nil {
+		return err
+	}
+	rec.OutputRaw = rawOutput
+	rec.Output = string(convertedOutput)
+	if strings.TrimSpace(rec.Command) != "" {
+		convertedCmd, err := simenc.ToUTF8(enc, []byte(rec.Command))
+		if err != nil {
+			return err
+		}
+		rec.Command = string(convertedCmd)
+	}
+	return nil
 }
 
+// SimDeviceCmdHandler 针对命名空间与设备的模拟命令处理器
+// 路由建议：/api/v1/sim-device-cmds
+// 支持：查询（按namespace、device_name、enabled）、创建、查看、更新、删除
+
+type SimDeviceCmdHandler struct{}
+
+func NewSimDeviceCmdHandler() *SimDeviceCmdHandler { return &SimDeviceCmdHandler{} }
+
 // CreateSimDeviceCmd 创建模拟命令（同设备同命令唯一：如已存在则更新最新回显）
 func (h *SimDeviceCmdHandler) CreateSimDeviceCmd(c *gin.Context) {
-	var req model.SimDeviceCommand
-	if err := c.ShouldBindJSON(&req); err != nil {
+	var body simDeviceCmdUpsertRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_PARAMS", "message": "参数错误: " + err.Error()})
 		return
 	}
+	req := body.SimDeviceCommand
+	if err := applyRecordEncoding(body.Encoding, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_ENCODING", "message": "字符集转换失败: " + err.Error()})
+		return
+	}
 	req.Namespace = strings.TrimSpace(req.Namespace)
 	req.DeviceName = strings.TrimSpace(req.DeviceName)
 	req.Command = strings.TrimSpace(req.Command)
@@ -67,12 +122,13 @@ func (h *SimDeviceCmdHandler) CreateSimDeviceCmd(c *gin.Context) {
 	var existing model.SimDeviceCommand
 	if err := db.Where("namespace = ? AND device_name = ? AND LOWER(command) = LOWER(?)", req.Namespace, req.DeviceName, req.Command).First(&existing).Error; err == nil {
 		// 已存在：更新其回显与启用状态，保留最新
-		update := map[string]interface{}{"output": req.Output, "enabled": req.Enabled}
+		update := map[string]interface{}{"output": req.Output, "output_raw": req.OutputRaw, "enabled": req.Enabled}
 		if err := database.WithRetry(func(d *gorm.DB) error { return d.Model(&existing).Updates(update).Error }, 6, 100*time.Millisecond); err != nil {
 			logger.Error("Upsert sim device command failed", "error", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"code": "UPSERT_FAILED", "message": "更新失败: " + err.Error()})
 			return
 		}
+		invalidateSimTrie(existing.Namespace, existing.DeviceName)
 		c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "已更新现有记录", "data": existing})
 		return
 	}
@@ -82,6 +138,7 @@ func (h *SimDeviceCmdHandler) CreateSimDeviceCmd(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": "CREATE_FAILED", "message": "创建失败: " + err.Error()})
 		return
 	}
+	invalidateSimTrie(req.Namespace, req.DeviceName)
 	c.JSON(http.StatusCreated, gin.H{"code": "SUCCESS", "message": "创建成功", "data": req})
 }
 
@@ -141,11 +198,16 @@ func (h *SimDeviceCmdHandler) UpdateSimDeviceCmd(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_ID", "message": "ID不合法"})
 		return
 	}
-	var req model.SimDeviceCommand
-	if err := c.ShouldBindJSON(&req); err != nil {
+	var body simDeviceCmdUpsertRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_PARAMS", "message": "参数错误: " + err.Error()})
 		return
 	}
+	req := body.SimDeviceCommand
+	if err := applyRecordEncoding(body.Encoding, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_ENCODING", "message": "字符集转换失败: " + err.Error()})
+		return
+	}
 
 	db := database.GetDB()
 	var item model.SimDeviceCommand
@@ -155,18 +217,29 @@ func (h *SimDeviceCmdHandler) UpdateSimDeviceCmd(c *gin.Context) {
 	}
 
 	newNS := strings.TrimSpace(req.Namespace)
-	if newNS == "" { newNS = item.Namespace }
+	if newNS == "" {
+		newNS = item.Namespace
+	}
 	newDev := strings.TrimSpace(req.DeviceName)
-	if newDev == "" { newDev = item.DeviceName }
+	if newDev == "" {
+		newDev = item.DeviceName
+	}
 	newCmd := strings.TrimSpace(req.Command)
-	if newCmd == "" { newCmd = item.Command }
+	if newCmd == "" {
+		newCmd = item.Command
+	}
 
 	// 查找是否存在另一条同设备同命令记录（忽略大小写）
 	var other model.SimDeviceCommand
 	if err := db.Where("namespace = ? AND device_name = ? AND LOWER(command) = LOWER(?)", newNS, newDev, newCmd).First(&other).Error; err == nil && other.ID != item.ID {
 		// 合并：更新另一个记录的输出与启用状态，删除当前记录
 		upd := map[string]interface{}{}
-		if strings.TrimSpace(req.Output) != "" { upd["output"] = req.Output } else { upd["output"] = item.Output }
+		if strings.TrimSpace(req.Output) != "" {
+			upd["output"] = req.Output
+			upd["output_raw"] = req.OutputRaw
+		} else {
+			upd["output"] = item.Output
+		}
 		upd["enabled"] = req.Enabled
 		if err := database.WithRetry(func(d *gorm.DB) error { return d.Model(&other).Updates(upd).Error }, 6, 100*time.Millisecond); err != nil {
 			logger.Error("Merge sim device command failed", "error", err)
@@ -174,22 +247,36 @@ func (h *SimDeviceCmdHandler) UpdateSimDeviceCmd(c *gin.Context) {
 			return
 		}
 		_ = database.WithRetry(func(d *gorm.DB) error { return d.Delete(&item).Error }, 6, 100*time.Millisecond)
+		invalidateSimTrie(item.Namespace, item.DeviceName)
+		invalidateSimTrie(other.Namespace, other.DeviceName)
 		c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "已合并到唯一记录", "data": other})
 		return
 	}
 
 	// 正常更新当前记录
 	update := map[string]interface{}{}
-	if newNS != item.Namespace { update["namespace"] = newNS }
-	if newDev != item.DeviceName { update["device_name"] = newDev }
-	if newCmd != item.Command { update["command"] = newCmd }
-	if strings.TrimSpace(req.Output) != "" { update["output"] = req.Output }
+	if newNS != item.Namespace {
+		update["namespace"] = newNS
+	}
+	if newDev != item.DeviceName {
+		update["device_name"] = newDev
+	}
+	if newCmd != item.Command {
+		update["command"] = newCmd
+	}
+	if strings.TrimSpace(req.Output) != "" {
+		update["output"] = req.Output
+		update["output_raw"] = req.OutputRaw
+	}
 	update["enabled"] = req.Enabled
+	origNS, origDev := item.Namespace, item.DeviceName
 	if err := database.WithRetry(func(d *gorm.DB) error { return d.Model(&item).Updates(update).Error }, 6, 100*time.Millisecond); err != nil {
 		logger.Error("Update sim device command failed", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"code": "UPDATE_FAILED", "message": "更新失败: " + err.Error()})
 		return
 	}
+	invalidateSimTrie(origNS, origDev)
+	invalidateSimTrie(newNS, newDev)
 	c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "更新成功", "data": item})
 }
 
@@ -201,74 +288,402 @@ func (h *SimDeviceCmdHandler) DeleteSimDeviceCmd(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_ID", "message": "ID不合法"})
 		return
 	}
+	db := database.GetDB()
+	var item model.SimDeviceCommand
+	if err := db.First(&item, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": "NOT_FOUND", "message": "记录不存在"})
+		return
+	}
 	// 并发保护：检测到 SQLite Busy 时进行短暂重试
 	if err := database.WithRetry(func(d *gorm.DB) error { return d.Delete(&model.SimDeviceCommand{}, id).Error }, 6, 100*time.Millisecond); err != nil {
 		logger.Error("Delete sim device command failed", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"code": "DELETE_FAILED", "message": "删除失败: " + err.Error()})
 		return
 	}
+	invalidateSimTrie(item.Namespace, item.DeviceName)
 	c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "删除成功"})
 }
 
-// 新增：按命名空间与设备进行命令模糊匹配，返回模拟回显或候选列表
-func (h *SimDeviceCmdHandler) MatchSimDeviceCmd(c *gin.Context) {
-	var req struct {
-		Namespace  string `json:"namespace"`
-		DeviceName string `json:"device_name"`
-		Command    string `json:"command"`
-		EnabledOnly bool  `json:"enabled_only"`
-	}
+// simMatchRequest 是匹配/帮助/补全三个端点共用的请求体
+type simMatchRequest struct {
+	Namespace  string `json:"namespace"`
+	DeviceName string `json:"device_name"`
+	Command    string `json:"command"`
+}
+
+func bindSimMatchRequest(c *gin.Context) (simMatchRequest, bool) {
+	var req simMatchRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_PARAMS", "message": "参数错误: " + err.Error()})
+		return req, false
+	}
+	req.Namespace = strings.TrimSpace(req.Namespace)
+	req.DeviceName = strings.TrimSpace(req.DeviceName)
+	if req.Namespace == "" || req.DeviceName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "MISSING_FIELDS", "message": "namespace 与 device_name 不能为空"})
+		return req, false
+	}
+	return req, true
+}
+
+// MatchSimDeviceCmd 按命名空间与设备对命令进行 trie 前缀匹配，支持词级唯一前缀展开。
+// 与早期版本相比，歧义只在产生歧义的那个词位置上提示候选，而非整条命令的候选列表。
+func (h *SimDeviceCmdHandler) MatchSimDeviceCmd(c *gin.Context) {
+	req, ok := bindSimMatchRequest(c)
+	if !ok {
 		return
 	}
-	ns := strings.TrimSpace(req.Namespace)
-	dev := strings.TrimSpace(req.DeviceName)
 	input := strings.TrimSpace(req.Command)
-	if ns == "" || dev == "" || input == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"code": "MISSING_FIELDS", "message": "namespace、device_name 与 command 不能为空"})
+	if input == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "MISSING_FIELDS", "message": "command 不能为空"})
 		return
 	}
 
-	db := database.GetDB()
-	var items []model.SimDeviceCommand
-	q := db.Where("namespace = ? AND device_name = ?", ns, dev)
-	if req.EnabledOnly { q = q.Where("enabled = 1") }
-	if err := q.Find(&items).Error; err != nil {
+	tr, err := getSimTrie(req.Namespace, req.DeviceName)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": "DB_ERROR", "message": "查询失败: " + err.Error()})
 		return
 	}
 
-	inNorm := normalizeCommand(input)
-	var exact *model.SimDeviceCommand
-	var candidates []model.SimDeviceCommand
-	for _, it := range items {
-		cmdNorm := normalizeCommand(it.Command)
-		if inNorm == cmdNorm {
-			exact = &it
-			break
+	personality := getSimDevicePersonality(req.Namespace, req.DeviceName)
+	var prompt string
+	if personality != nil {
+		prompt = renderPersonalityTemplate(personality.PromptTemplate, map[string]string{"hostname": req.DeviceName, "privilege": "1"})
+	}
+
+	result := tr.Match(input)
+	switch result.Type {
+	case simtrie.MatchExact, simtrie.MatchSingle:
+		output := result.Entry.Output
+		truncated := false
+		if personality != nil {
+			output, truncated = applyPersonalityPaging(personality, output)
+		}
+		c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": string(result.Type), "data": gin.H{"match_type": result.Type, "output": output, "prompt": prompt, "more": truncated}})
+	case simtrie.MatchAmbig:
+		var lines []string
+		lines = append(lines, "which command do you mean?")
+		for _, cand := range result.Candidates {
+			lines = append(lines, " -- "+cand)
+		}
+		c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "ambiguous_token", "data": gin.H{"match_type": result.Type, "token_index": result.TokenIndex, "candidates": result.Candidates, "output": strings.Join(lines, "\n"), "prompt": prompt}})
+	case simtrie.MatchMulti:
+		var lines []string
+		lines = append(lines, "which command do you mean?")
+		for _, cand := range result.Candidates {
+			lines = append(lines, " -- "+cand)
 		}
-		if prefixMatchByWords(input, it.Command) {
-			candidates = append(candidates, it)
+		c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "partial_multi", "data": gin.H{"match_type": result.Type, "candidates": result.Candidates, "output": strings.Join(lines, "\n"), "prompt": prompt}})
+	default:
+		output := "unspport command"
+		if personality != nil && personality.UnknownCommandTemplate != "" {
+			output = renderUnknownCommandOutput(personality, input, result.TokenIndex)
 		}
+		c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "none", "data": gin.H{"match_type": result.Type, "output": output, "prompt": prompt}})
 	}
+}
 
-	if exact != nil {
-		c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "exact", "data": gin.H{"match_type": "exact", "output": exact.Output}})
+// HelpSimDeviceCmd 实现终端 "?" 语义：返回当前输入位置上所有合法的下一个词
+func (h *SimDeviceCmdHandler) HelpSimDeviceCmd(c *gin.Context) {
+	req, ok := bindSimMatchRequest(c)
+	if !ok {
 		return
 	}
-	if len(candidates) == 1 {
-		c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "partial_single", "data": gin.H{"match_type": "partial_single", "output": candidates[0].Output}})
+	tr, err := getSimTrie(req.Namespace, req.DeviceName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "DB_ERROR", "message": "查询失败: " + err.Error()})
 		return
 	}
-	if len(candidates) > 1 {
-		var lines []string
-		lines = append(lines, "which command do you mean?")
-		for _, it := range candidates {
-			lines = append(lines, " -- " + strings.TrimSpace(it.Command))
+	tokens := tr.Help(req.Command)
+	c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "查询成功", "data": gin.H{"candidates": tokens}})
+}
+
+// CompleteSimDeviceCmd 实现 TAB 补全：返回当前词位置上所有候选的最长公共前缀
+func (h *SimDeviceCmdHandler) CompleteSimDeviceCmd(c *gin.Context) {
+	req, ok := bindSimMatchRequest(c)
+	if !ok {
+		return
+	}
+	tr, err := getSimTrie(req.Namespace, req.DeviceName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "DB_ERROR", "message": "查询失败: " + err.Error()})
+		return
+	}
+	completed, candidates := tr.CompletePrefix(req.Command)
+	c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "查询成功", "data": gin.H{"completed": completed, "candidates": candidates}})
+}
+
+// decodeSimDeviceCmdBundle 按格式将原始字节解析为待导入的行列表
+func decodeSimDeviceCmdBundle(format string, raw []byte) ([]simDeviceCmdBundleRow, error) {
+	switch format {
+	case bundleFormatJSON:
+		var rows []simDeviceCmdBundleRow
+		if err := json.Unmarshal(raw, &rows); err != nil {
+			return nil, err
+		}
+		return rows, nil
+	case bundleFormatYAML:
+		var rows []simDeviceCmdBundleRow
+		if err := yaml.Unmarshal(raw, &rows); err != nil {
+			return nil, err
+		}
+		return rows, nil
+	case bundleFormatCSV:
+		r := csv.NewReader(strings.NewReader(string(raw)))
+		r.TrimLeadingSpace = true
+		records, err := r.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, nil
+		}
+		header := records[0]
+		col := make(map[string]int, len(header))
+		for i, name := range header {
+			col[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+		var rows []simDeviceCmdBundleRow
+		for _, rec := range records[1:] {
+			get := func(key string) string {
+				if idx, ok := col[key]; ok && idx < len(rec) {
+					return rec[idx]
+				}
+				return ""
+			}
+			rows = append(rows, simDeviceCmdBundleRow{
+				Namespace:  get("namespace"),
+				DeviceName: get("device_name"),
+				Command:    get("command"),
+				Output:     get("output"),
+				Enabled:    strings.EqualFold(get("enabled"), "true") || get("enabled") == "1",
+				Encoding:   get("encoding"),
+			})
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("不支持的格式: %s", format)
+	}
+}
+
+// resolveBundleFormat 根据显式 format 参数或文件名后缀推断批量格式
+func resolveBundleFormat(explicit string, filename string) string {
+	f := strings.ToLower(strings.TrimSpace(explicit))
+	if f == "yml" {
+		f = bundleFormatYAML
+	}
+	if f == bundleFormatYAML || f == bundleFormatJSON || f == bundleFormatCSV {
+		return f
+	}
+	lower := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(lower, ".yaml"), strings.HasSuffix(lower, ".yml"):
+		return bundleFormatYAML
+	case strings.HasSuffix(lower, ".csv"):
+		return bundleFormatCSV
+	default:
+		return bundleFormatJSON
+	}
+}
+
+// ImportSimDeviceCmds 批量导入模拟命令（YAML/JSON/CSV），支持 multipart 文件上传
+// 查询参数：format（可选，按文件名后缀推断）、conflict（skip/overwrite/merge-output，默认 skip）、
+// encoding（可选，整批默认字符集，如 gbk/gb18030/big5/iso-8859-1/shift_jis，逐行 encoding 列可覆盖）
+func (h *SimDeviceCmdHandler) ImportSimDeviceCmds(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "MISSING_FILE", "message": "缺少上传文件: " + err.Error()})
+		return
+	}
+	defer file.Close()
+
+	defaultEncoding := strings.TrimSpace(c.Query("encoding"))
+	conflict := strings.ToLower(strings.TrimSpace(c.Query("conflict")))
+	if conflict == "" {
+		conflict = conflictPolicySkip
+	}
+	if conflict != conflictPolicySkip && conflict != conflictPolicyOverwrite && conflict != conflictPolicyMergeOutput {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_CONFLICT_POLICY", "message": "conflict 仅支持 skip/overwrite/merge-output"})
+		return
+	}
+
+	format := resolveBundleFormat(c.Query("format"), header.Filename)
+	raw, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "READ_FILE_FAILED", "message": "读取上传文件失败: " + err.Error()})
+		return
+	}
+
+	rows, err := decodeSimDeviceCmdBundle(format, raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "DECODE_FAILED", "message": "解析文件失败: " + err.Error()})
+		return
+	}
+
+	defaultNS := strings.TrimSpace(c.Query("namespace"))
+	defaultDev := strings.TrimSpace(c.Query("device_name"))
+
+	results := make([]simDeviceCmdImportRowResult, 0, len(rows))
+	var created, updated, skipped, failed int
+	touchedScopes := map[[2]string]struct{}{}
+
+	err = database.WithRetry(func(d *gorm.DB) error {
+		return d.Transaction(func(tx *gorm.DB) error {
+			for i, row := range rows {
+				rowNum := i + 1
+				rowEncoding := strings.TrimSpace(row.Encoding)
+				if rowEncoding == "" {
+					rowEncoding = defaultEncoding
+				}
+				rec := model.SimDeviceCommand{Command: row.Command, Output: row.Output}
+				if err := applyRecordEncoding(rowEncoding, &rec); err != nil {
+					failed++
+					results = append(results, simDeviceCmdImportRowResult{Row: rowNum, Action: "failed", Command: row.Command, Error: "字符集转换失败: " + err.Error()})
+					continue
+				}
+				row.Command, row.Output = rec.Command, rec.Output
+
+				ns := strings.TrimSpace(row.Namespace)
+				if ns == "" {
+					ns = defaultNS
+				}
+				dev := strings.TrimSpace(row.DeviceName)
+				if dev == "" {
+					dev = defaultDev
+				}
+				cmd := strings.TrimSpace(row.Command)
+				if ns == "" || dev == "" || cmd == "" {
+					failed++
+					results = append(results, simDeviceCmdImportRowResult{Row: rowNum, Action: "failed", Command: cmd, Error: "namespace、device_name 与 command 不能为空"})
+					continue
+				}
+				touchedScopes[[2]string{ns, dev}] = struct{}{}
+
+				var existing model.SimDeviceCommand
+				findErr := tx.Where("namespace = ? AND device_name = ? AND LOWER(command) = LOWER(?)", ns, dev, cmd).First(&existing).Error
+				switch {
+				case findErr == nil && conflict == conflictPolicySkip:
+					skipped++
+					results = append(results, simDeviceCmdImportRowResult{Row: rowNum, Action: "skipped", Command: cmd})
+				case findErr == nil && conflict == conflictPolicyOverwrite:
+					if err := tx.Model(&existing).Updates(map[string]interface{}{"output": row.Output, "output_raw": rec.OutputRaw, "enabled": row.Enabled}).Error; err != nil {
+						failed++
+						results = append(results, simDeviceCmdImportRowResult{Row: rowNum, Action: "failed", Command: cmd, Error: err.Error()})
+						continue
+					}
+					updated++
+					results = append(results, simDeviceCmdImportRowResult{Row: rowNum, Action: "updated", Command: cmd})
+				case findErr == nil && conflict == conflictPolicyMergeOutput:
+					merged := existing.Output
+					if strings.TrimSpace(row.Output) != "" && strings.TrimSpace(row.Output) != strings.TrimSpace(existing.Output) {
+						merged = strings.TrimRight(existing.Output, "\n") + "\n" + row.Output
+					}
+					if err := tx.Model(&existing).Updates(map[string]interface{}{"output": merged, "enabled": row.Enabled}).Error; err != nil {
+						failed++
+						results = append(results, simDeviceCmdImportRowResult{Row: rowNum, Action: "failed", Command: cmd, Error: err.Error()})
+						continue
+					}
+					updated++
+					results = append(results, simDeviceCmdImportRowResult{Row: rowNum, Action: "updated", Command: cmd})
+				case errors.Is(findErr, gorm.ErrRecordNotFound):
+					newRec := model.SimDeviceCommand{Namespace: ns, DeviceName: dev, Command: cmd, Output: row.Output, OutputRaw: rec.OutputRaw, Enabled: row.Enabled}
+					if err := tx.Create(&newRec).Error; err != nil {
+						failed++
+						results = append(results, simDeviceCmdImportRowResult{Row: rowNum, Action: "failed", Command: cmd, Error: err.Error()})
+						continue
+					}
+					created++
+					results = append(results, simDeviceCmdImportRowResult{Row: rowNum, Action: "created", Command: cmd})
+				default:
+					// 非"未找到"的查询错误（如连接中断）不应被当作新记录静默创建，
+					// 需作为该行的失败原样上抛，避免批量导入时掩盖真实问题
+					failed++
+					results = append(results, simDeviceCmdImportRowResult{Row: rowNum, Action: "failed", Command: cmd, Error: findErr.Error()})
+				}
+			}
+			return nil
+		})
+	}, 6, 100*time.Millisecond)
+
+	if err != nil {
+		logger.Error("Import sim device commands failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "IMPORT_FAILED", "message": "导入失败: " + err.Error()})
+		return
+	}
+	for scope := range touchedScopes {
+		invalidateSimTrie(scope[0], scope[1])
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "导入完成", "data": gin.H{
+		"total":   len(rows),
+		"created": created,
+		"updated": updated,
+		"skipped": skipped,
+		"failed":  failed,
+		"results": results,
+	}})
+}
+
+// ExportSimDeviceCmds 按命名空间/设备/启用状态筛选并流式导出模拟命令（YAML/JSON/CSV）
+func (h *SimDeviceCmdHandler) ExportSimDeviceCmds(c *gin.Context) {
+	ns := strings.TrimSpace(c.Query("namespace"))
+	dev := strings.TrimSpace(c.Query("device_name"))
+	enabledQ := strings.TrimSpace(c.Query("enabled"))
+	format := resolveBundleFormat(c.Query("format"), "")
+
+	db := database.GetDB()
+	q := db.Model(&model.SimDeviceCommand{})
+	if ns != "" {
+		q = q.Where("namespace = ?", ns)
+	}
+	if dev != "" {
+		q = q.Where("device_name = ?", dev)
+	}
+	if enabledQ != "" {
+		switch enabledQ {
+		case "true":
+			q = q.Where("enabled = 1")
+		case "false":
+			q = q.Where("enabled = 0")
 		}
-		c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "partial_multi", "data": gin.H{"match_type": "partial_multi", "output": strings.Join(lines, "\n")}})
+	}
+
+	var items []model.SimDeviceCommand
+	if err := q.Order("namespace, device_name, command").Find(&items).Error; err != nil {
+		logger.Error("Export sim device commands failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "EXPORT_FAILED", "message": "查询失败: " + err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "none", "data": gin.H{"match_type": "none", "output": "unspport command"}})
+
+	rows := make([]simDeviceCmdBundleRow, 0, len(items))
+	for _, it := range items {
+		rows = append(rows, simDeviceCmdBundleRow{Namespace: it.Namespace, DeviceName: it.DeviceName, Command: it.Command, Output: it.Output, Enabled: it.Enabled})
+	}
+
+	filename := fmt.Sprintf("sim-device-cmds.%s", format)
+	c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+
+	switch format {
+	case bundleFormatYAML:
+		c.Header("Content-Type", "application/x-yaml")
+		enc := yaml.NewEncoder(c.Writer)
+		defer enc.Close()
+		if err := enc.Encode(rows); err != nil {
+			logger.Error("Encode yaml export failed", "error", err)
+		}
+	case bundleFormatCSV:
+		c.Header("Content-Type", "text/csv")
+		w := csv.NewWriter(c.Writer)
+		_ = w.Write([]string{"namespace", "device_name", "command", "output", "enabled"})
+		for _, row := range rows {
+			_ = w.Write([]string{row.Namespace, row.DeviceName, row.Command, row.Output, strconv.FormatBool(row.Enabled)})
+		}
+		w.Flush()
+	default:
+		c.Header("Content-Type", "application/json")
+		enc := json.NewEncoder(c.Writer)
+		if err := enc.Encode(rows); err != nil {
+			logger.Error("Encode json export failed", "error", err)
+		}
+	}
 }
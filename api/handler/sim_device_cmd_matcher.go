@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"sync"
+
+	"github.com/sshcollectorpro/sshcollectorpro/internal/database"
+	"github.com/sshcollectorpro/sshcollectorpro/internal/model"
+	"github.com/sshcollectorpro/sshcollectorpro/internal/simtrie"
+)
+
+// simTrieCache 按 (namespace, device_name) 缓存已构建的命令 trie，
+// 惰性构建并在对应范围的命令发生增删改时失效，下次匹配时重新加载
+type simTrieCache struct {
+	mu    sync.RWMutex
+	tries map[string]*simtrie.Trie
+}
+
+var simMatcherCache = &simTrieCache{tries: map[string]*simtrie.Trie{}}
+
+func simTrieCacheKey(namespace, deviceName string) string {
+	return namespace + "\x00" + deviceName
+}
+
+// invalidateSimTrie 使指定命名空间/设备的缓存失效
+func invalidateSimTrie(namespace, deviceName string) {
+	simMatcherCache.mu.Lock()
+	defer simMatcherCache.mu.Unlock()
+	delete(simMatcherCache.tries, simTrieCacheKey(namespace, deviceName))
+}
+
+// getSimTrie 返回指定命名空间/设备的 trie；缓存未命中时从数据库加载已启用命令并构建
+func getSimTrie(namespace, deviceName string) (*simtrie.Trie, error) {
+	key := simTrieCacheKey(namespace, deviceName)
+
+	simMatcherCache.mu.RLock()
+	tr, ok := simMatcherCache.tries[key]
+	simMatcherCache.mu.RUnlock()
+	if ok {
+		return tr, nil
+	}
+
+	simMatcherCache.mu.Lock()
+	defer simMatcherCache.mu.Unlock()
+	// 双重检查：等待锁期间可能已被其他请求构建
+	if tr, ok := simMatcherCache.tries[key]; ok {
+		return tr, nil
+	}
+
+	var items []model.SimDeviceCommand
+	if err := database.GetDB().Where("namespace = ? AND device_name = ? AND enabled = 1", namespace, deviceName).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	entries := make([]simtrie.Entry, 0, len(items))
+	for _, it := range items {
+		entries = append(entries, simtrie.Entry{ID: it.ID, Command: it.Command, Output: it.Output})
+	}
+	tr = simtrie.Build(entries)
+	simMatcherCache.tries[key] = tr
+	return tr, nil
+}
@@ -0,0 +1,383 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sshcollectorpro/sshcollectorpro/internal/database"
+	"github.com/sshcollectorpro/sshcollectorpro/internal/model"
+	"github.com/sshcollectorpro/sshcollectorpro/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// SimDeviceCmdSnapshotHandler 为模拟命令库提供 Git 风格的版本化能力：
+// 按命名空间（及可选设备）捕获快照、查看、回滚与两版本间差异比对
+// 路由建议：/api/v1/sim-device-cmds/snapshots
+type SimDeviceCmdSnapshotHandler struct{}
+
+func NewSimDeviceCmdSnapshotHandler() *SimDeviceCmdSnapshotHandler {
+	return &SimDeviceCmdSnapshotHandler{}
+}
+
+// simDeviceCmdSnapshotSummary 是快照列表/详情中除原始 Blob 外对外展示的字段
+type simDeviceCmdSnapshotSummary struct {
+	ID         uint      `json:"id"`
+	Namespace  string    `json:"namespace"`
+	DeviceName string    `json:"device_name"`
+	Revision   int       `json:"revision"`
+	Author     string    `json:"author"`
+	Message    string    `json:"message"`
+	CreatedAt  time.Time `json:"created_at"`
+	Commands   int       `json:"commands"`
+}
+
+func compressCommands(items []model.SimDeviceCommand) ([]byte, error) {
+	raw, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompressCommands(blob []byte) ([]model.SimDeviceCommand, error) {
+	gr, err := gzip.NewReader(bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+	raw, err := io.ReadAll(gr)
+	if err != nil {
+		return nil, err
+	}
+	var items []model.SimDeviceCommand
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func snapshotScopeKey(namespace, deviceName, command string) string {
+	return namespace + "\x00" + deviceName + "\x00" + strings.ToLower(strings.TrimSpace(command))
+}
+
+// simDeviceCmdDiffEntry 描述某条命令在两个版本间的差异
+type simDeviceCmdDiffEntry struct {
+	Namespace  string `json:"namespace"`
+	DeviceName string `json:"device_name"`
+	Command    string `json:"command"`
+	OldOutput  string `json:"old_output,omitempty"`
+	NewOutput  string `json:"new_output,omitempty"`
+}
+
+// diffSimDeviceCommands 比较两个命令集合，返回新增/删除/变更（按 namespace+device+command 判定）
+func diffSimDeviceCommands(oldItems, newItems []model.SimDeviceCommand) (added, removed, changed []simDeviceCmdDiffEntry) {
+	oldByKey := make(map[string]model.SimDeviceCommand, len(oldItems))
+	for _, it := range oldItems {
+		oldByKey[snapshotScopeKey(it.Namespace, it.DeviceName, it.Command)] = it
+	}
+	newByKey := make(map[string]model.SimDeviceCommand, len(newItems))
+	for _, it := range newItems {
+		newByKey[snapshotScopeKey(it.Namespace, it.DeviceName, it.Command)] = it
+	}
+
+	for key, n := range newByKey {
+		o, ok := oldByKey[key]
+		if !ok {
+			added = append(added, simDeviceCmdDiffEntry{Namespace: n.Namespace, DeviceName: n.DeviceName, Command: n.Command, NewOutput: n.Output})
+			continue
+		}
+		if o.Output != n.Output || o.Enabled != n.Enabled {
+			changed = append(changed, simDeviceCmdDiffEntry{Namespace: n.Namespace, DeviceName: n.DeviceName, Command: n.Command, OldOutput: o.Output, NewOutput: n.Output})
+		}
+	}
+	for key, o := range oldByKey {
+		if _, ok := newByKey[key]; !ok {
+			removed = append(removed, simDeviceCmdDiffEntry{Namespace: o.Namespace, DeviceName: o.DeviceName, Command: o.Command, OldOutput: o.Output})
+		}
+	}
+	return added, removed, changed
+}
+
+// CreateSnapshot 按命名空间（及可选设备）捕获当前命令库的一份快照
+func (h *SimDeviceCmdSnapshotHandler) CreateSnapshot(c *gin.Context) {
+	var req struct {
+		Namespace  string `json:"namespace"`
+		DeviceName string `json:"device_name"`
+		Author     string `json:"author"`
+		Message    string `json:"message"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_PARAMS", "message": "参数错误: " + err.Error()})
+		return
+	}
+	req.Namespace = strings.TrimSpace(req.Namespace)
+	req.DeviceName = strings.TrimSpace(req.DeviceName)
+	if req.Namespace == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "MISSING_FIELDS", "message": "namespace 不能为空"})
+		return
+	}
+
+	db := database.GetDB()
+	q := db.Where("namespace = ?", req.Namespace)
+	if req.DeviceName != "" {
+		q = q.Where("device_name = ?", req.DeviceName)
+	}
+	var items []model.SimDeviceCommand
+	if err := q.Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "DB_ERROR", "message": "查询失败: " + err.Error()})
+		return
+	}
+
+	blob, err := compressCommands(items)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "ENCODE_FAILED", "message": "快照编码失败: " + err.Error()})
+		return
+	}
+
+	snapshot := model.SimDeviceCmdSnapshot{
+		Namespace:  req.Namespace,
+		DeviceName: req.DeviceName,
+		Author:     req.Author,
+		Message:    req.Message,
+		Blob:       blob,
+	}
+	err = database.WithRetry(func(d *gorm.DB) error {
+		return d.Transaction(func(tx *gorm.DB) error {
+			var maxRevision int
+			row := tx.Model(&model.SimDeviceCmdSnapshot{}).
+				Where("namespace = ? AND device_name = ?", req.Namespace, req.DeviceName).
+				Select("COALESCE(MAX(revision), 0)").Row()
+			if err := row.Scan(&maxRevision); err != nil {
+				return err
+			}
+			snapshot.Revision = maxRevision + 1
+			return tx.Create(&snapshot).Error
+		})
+	}, 6, 100*time.Millisecond)
+	if err != nil {
+		logger.Error("Create sim device cmd snapshot failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "SNAPSHOT_FAILED", "message": "创建快照失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"code": "SUCCESS", "message": "创建成功", "data": simDeviceCmdSnapshotSummary{
+		ID: snapshot.ID, Namespace: snapshot.Namespace, DeviceName: snapshot.DeviceName,
+		Revision: snapshot.Revision, Author: snapshot.Author, Message: snapshot.Message,
+		CreatedAt: snapshot.CreatedAt, Commands: len(items),
+	}})
+}
+
+// ListSnapshots 列出快照（按命名空间与设备筛选），不返回原始压缩内容
+func (h *SimDeviceCmdSnapshotHandler) ListSnapshots(c *gin.Context) {
+	ns := strings.TrimSpace(c.Query("namespace"))
+	dev := strings.TrimSpace(c.Query("device_name"))
+
+	db := database.GetDB()
+	q := db.Model(&model.SimDeviceCmdSnapshot{})
+	if ns != "" {
+		q = q.Where("namespace = ?", ns)
+	}
+	if dev != "" {
+		q = q.Where("device_name = ?", dev)
+	}
+	var rows []model.SimDeviceCmdSnapshot
+	if err := q.Order("revision DESC").Find(&rows).Error; err != nil {
+		logger.Error("List sim device cmd snapshots failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "LIST_FAILED", "message": "查询失败: " + err.Error()})
+		return
+	}
+
+	summaries := make([]simDeviceCmdSnapshotSummary, 0, len(rows))
+	for _, row := range rows {
+		commands, err := decompressCommands(row.Blob)
+		count := 0
+		if err == nil {
+			count = len(commands)
+		}
+		summaries = append(summaries, simDeviceCmdSnapshotSummary{
+			ID: row.ID, Namespace: row.Namespace, DeviceName: row.DeviceName,
+			Revision: row.Revision, Author: row.Author, Message: row.Message,
+			CreatedAt: row.CreatedAt, Commands: count,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "查询成功", "data": summaries})
+}
+
+func loadSnapshot(id int) (model.SimDeviceCmdSnapshot, error) {
+	var row model.SimDeviceCmdSnapshot
+	err := database.GetDB().First(&row, id).Error
+	return row, err
+}
+
+// GetSnapshot 查看单条快照详情，并附带其与当前命令库的差异
+func (h *SimDeviceCmdSnapshotHandler) GetSnapshot(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_ID", "message": "ID不合法"})
+		return
+	}
+	snapshot, err := loadSnapshot(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": "NOT_FOUND", "message": "快照不存在"})
+		return
+	}
+	snapshotCommands, err := decompressCommands(snapshot.Blob)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "DECODE_FAILED", "message": "快照解码失败: " + err.Error()})
+		return
+	}
+
+	db := database.GetDB()
+	q := db.Where("namespace = ?", snapshot.Namespace)
+	if snapshot.DeviceName != "" {
+		q = q.Where("device_name = ?", snapshot.DeviceName)
+	}
+	var current []model.SimDeviceCommand
+	if err := q.Find(&current).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "DB_ERROR", "message": "查询失败: " + err.Error()})
+		return
+	}
+	added, removed, changed := diffSimDeviceCommands(snapshotCommands, current)
+
+	c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "查询成功", "data": gin.H{
+		"snapshot": simDeviceCmdSnapshotSummary{
+			ID: snapshot.ID, Namespace: snapshot.Namespace, DeviceName: snapshot.DeviceName,
+			Revision: snapshot.Revision, Author: snapshot.Author, Message: snapshot.Message,
+			CreatedAt: snapshot.CreatedAt, Commands: len(snapshotCommands),
+		},
+		"commands":        snapshotCommands,
+		"diff_vs_current": gin.H{"added": added, "removed": removed, "changed": changed},
+	}})
+}
+
+// DiffSnapshots 比较任意两个快照（通过 from/to 查询参数传入快照 ID）之间的差异
+func (h *SimDeviceCmdSnapshotHandler) DiffSnapshots(c *gin.Context) {
+	fromID, _ := strconv.Atoi(c.Query("from"))
+	toID, _ := strconv.Atoi(c.Query("to"))
+	if fromID <= 0 || toID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "MISSING_FIELDS", "message": "from 与 to 均需为有效的快照 ID"})
+		return
+	}
+	from, err := loadSnapshot(fromID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": "NOT_FOUND", "message": "起始快照不存在"})
+		return
+	}
+	to, err := loadSnapshot(toID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": "NOT_FOUND", "message": "目标快照不存在"})
+		return
+	}
+	fromCommands, err := decompressCommands(from.Blob)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "DECODE_FAILED", "message": "起始快照解码失败: " + err.Error()})
+		return
+	}
+	toCommands, err := decompressCommands(to.Blob)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "DECODE_FAILED", "message": "目标快照解码失败: " + err.Error()})
+		return
+	}
+	added, removed, changed := diffSimDeviceCommands(fromCommands, toCommands)
+	c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "查询成功", "data": gin.H{
+		"from": from.Revision, "to": to.Revision,
+		"added": added, "removed": removed, "changed": changed,
+	}})
+}
+
+// RollbackSnapshot 将指定快照的范围（namespace/device_name）原子性地还原为当前命令库，
+// 采用插入/更新而非清空重建，使重叠命令的 ID 保持稳定
+func (h *SimDeviceCmdSnapshotHandler) RollbackSnapshot(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_ID", "message": "ID不合法"})
+		return
+	}
+	snapshot, err := loadSnapshot(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": "NOT_FOUND", "message": "快照不存在"})
+		return
+	}
+	snapshotCommands, err := decompressCommands(snapshot.Blob)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "DECODE_FAILED", "message": "快照解码失败: " + err.Error()})
+		return
+	}
+
+	var created, updated, deleted int
+	touchedScopes := map[[2]string]struct{}{}
+	err = database.WithRetry(func(d *gorm.DB) error {
+		return d.Transaction(func(tx *gorm.DB) error {
+			q := tx.Where("namespace = ?", snapshot.Namespace)
+			if snapshot.DeviceName != "" {
+				q = q.Where("device_name = ?", snapshot.DeviceName)
+			}
+			var current []model.SimDeviceCommand
+			if err := q.Find(&current).Error; err != nil {
+				return err
+			}
+			currentByKey := make(map[string]model.SimDeviceCommand, len(current))
+			for _, it := range current {
+				currentByKey[snapshotScopeKey(it.Namespace, it.DeviceName, it.Command)] = it
+			}
+
+			for _, want := range snapshotCommands {
+				key := snapshotScopeKey(want.Namespace, want.DeviceName, want.Command)
+				touchedScopes[[2]string{want.Namespace, want.DeviceName}] = struct{}{}
+				if existing, ok := currentByKey[key]; ok {
+					if err := tx.Model(&existing).Updates(map[string]interface{}{
+						"command": want.Command, "output": want.Output, "output_raw": want.OutputRaw, "enabled": want.Enabled,
+					}).Error; err != nil {
+						return err
+					}
+					updated++
+					delete(currentByKey, key)
+					continue
+				}
+				newRec := want
+				newRec.ID = 0
+				if err := tx.Create(&newRec).Error; err != nil {
+					return err
+				}
+				created++
+			}
+
+			// 快照中不存在的当前记录予以删除，恢复到快照时的完整状态
+			for _, stale := range currentByKey {
+				if err := tx.Delete(&stale).Error; err != nil {
+					return err
+				}
+				deleted++
+				touchedScopes[[2]string{stale.Namespace, stale.DeviceName}] = struct{}{}
+			}
+			return nil
+		})
+	}, 6, 100*time.Millisecond)
+	if err != nil {
+		logger.Error("Rollback sim device cmd snapshot failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "ROLLBACK_FAILED", "message": "回滚失败: " + err.Error()})
+		return
+	}
+	for scope := range touchedScopes {
+		invalidateSimTrie(scope[0], scope[1])
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "回滚成功", "data": gin.H{
+		"revision": snapshot.Revision, "created": created, "updated": updated, "deleted": deleted,
+	}})
+}
@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sshcollectorpro/sshcollectorpro/internal/database"
+	"github.com/sshcollectorpro/sshcollectorpro/internal/model"
+	"github.com/sshcollectorpro/sshcollectorpro/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// SimDevicePersonalityHandler 针对命名空间与设备的"设备个性"处理器
+// 路由建议：/api/v1/sim-device-personalities
+// 支持：查询（按namespace、device_name）、创建（同设备唯一，存在则更新）、查看、更新、删除
+
+type SimDevicePersonalityHandler struct{}
+
+func NewSimDevicePersonalityHandler() *SimDevicePersonalityHandler {
+	return &SimDevicePersonalityHandler{}
+}
+
+const defaultPersonalityPagingLines = 24
+
+// CreateSimDevicePersonality 创建设备个性（同命名空间同设备唯一：如已存在则整体更新）
+func (h *SimDevicePersonalityHandler) CreateSimDevicePersonality(c *gin.Context) {
+	var req model.SimDevicePersonality
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_PARAMS", "message": "参数错误: " + err.Error()})
+		return
+	}
+	req.Namespace = strings.TrimSpace(req.Namespace)
+	req.DeviceName = strings.TrimSpace(req.DeviceName)
+	if req.Namespace == "" || req.DeviceName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "MISSING_FIELDS", "message": "namespace 与 device_name 不能为空"})
+		return
+	}
+	if req.PagingLines <= 0 {
+		req.PagingLines = defaultPersonalityPagingLines
+	}
+	if req.LineEnding == "" {
+		req.LineEnding = "\n"
+	}
+
+	db := database.GetDB()
+	var existing model.SimDevicePersonality
+	if err := db.Where("namespace = ? AND device_name = ?", req.Namespace, req.DeviceName).First(&existing).Error; err == nil {
+		update := map[string]interface{}{
+			"vendor":                   req.Vendor,
+			"prompt_template":          req.PromptTemplate,
+			"more_prompt":              req.MorePrompt,
+			"unknown_command_template": req.UnknownCommandTemplate,
+			"paging_lines":             req.PagingLines,
+			"line_ending":              req.LineEnding,
+		}
+		if err := database.WithRetry(func(d *gorm.DB) error { return d.Model(&existing).Updates(update).Error }, 6, 100*time.Millisecond); err != nil {
+			logger.Error("Upsert sim device personality failed", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": "UPSERT_FAILED", "message": "更新失败: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "已更新现有记录", "data": existing})
+		return
+	}
+
+	if err := database.WithRetry(func(d *gorm.DB) error { return d.Create(&req).Error }, 6, 100*time.Millisecond); err != nil {
+		logger.Error("Create sim device personality failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "CREATE_FAILED", "message": "创建失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"code": "SUCCESS", "message": "创建成功", "data": req})
+}
+
+// ListSimDevicePersonalities 列出设备个性（按命名空间与设备筛选）
+func (h *SimDevicePersonalityHandler) ListSimDevicePersonalities(c *gin.Context) {
+	ns := strings.TrimSpace(c.Query("namespace"))
+	dev := strings.TrimSpace(c.Query("device_name"))
+
+	db := database.GetDB()
+	var items []model.SimDevicePersonality
+	q := db.Model(&model.SimDevicePersonality{})
+	if ns != "" {
+		q = q.Where("namespace = ?", ns)
+	}
+	if dev != "" {
+		q = q.Where("device_name = ?", dev)
+	}
+	if err := q.Order("updated_at DESC").Find(&items).Error; err != nil {
+		logger.Error("List sim device personalities failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "LIST_FAILED", "message": "查询失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "查询成功", "data": items})
+}
+
+// GetSimDevicePersonality 查看单条设备个性
+func (h *SimDevicePersonalityHandler) GetSimDevicePersonality(c *gin.Context) {
+	idStr := c.Param("id")
+	id, _ := strconv.Atoi(idStr)
+	if id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_ID", "message": "ID不合法"})
+		return
+	}
+	db := database.GetDB()
+	var item model.SimDevicePersonality
+	if err := db.First(&item, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": "NOT_FOUND", "message": "记录不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "查询成功", "data": item})
+}
+
+// UpdateSimDevicePersonality 更新设备个性
+func (h *SimDevicePersonalityHandler) UpdateSimDevicePersonality(c *gin.Context) {
+	idStr := c.Param("id")
+	id, _ := strconv.Atoi(idStr)
+	if id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_ID", "message": "ID不合法"})
+		return
+	}
+	var req model.SimDevicePersonality
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_PARAMS", "message": "参数错误: " + err.Error()})
+		return
+	}
+
+	db := database.GetDB()
+	var item model.SimDevicePersonality
+	if err := db.First(&item, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": "NOT_FOUND", "message": "记录不存在"})
+		return
+	}
+
+	update := map[string]interface{}{}
+	if strings.TrimSpace(req.Vendor) != "" {
+		update["vendor"] = req.Vendor
+	}
+	if strings.TrimSpace(req.PromptTemplate) != "" {
+		update["prompt_template"] = req.PromptTemplate
+	}
+	if strings.TrimSpace(req.MorePrompt) != "" {
+		update["more_prompt"] = req.MorePrompt
+	}
+	if strings.TrimSpace(req.UnknownCommandTemplate) != "" {
+		update["unknown_command_template"] = req.UnknownCommandTemplate
+	}
+	if req.PagingLines > 0 {
+		update["paging_lines"] = req.PagingLines
+	}
+	if req.LineEnding != "" {
+		update["line_ending"] = req.LineEnding
+	}
+	if err := database.WithRetry(func(d *gorm.DB) error { return d.Model(&item).Updates(update).Error }, 6, 100*time.Millisecond); err != nil {
+		logger.Error("Update sim device personality failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "UPDATE_FAILED", "message": "更新失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "更新成功", "data": item})
+}
+
+// DeleteSimDevicePersonality 删除设备个性
+func (h *SimDevicePersonalityHandler) DeleteSimDevicePersonality(c *gin.Context) {
+	idStr := c.Param("id")
+	id, _ := strconv.Atoi(idStr)
+	if id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_ID", "message": "ID不合法"})
+		return
+	}
+	if err := database.WithRetry(func(d *gorm.DB) error { return d.Delete(&model.SimDevicePersonality{}, id).Error }, 6, 100*time.Millisecond); err != nil {
+		logger.Error("Delete sim device personality failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "DELETE_FAILED", "message": "删除失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": "SUCCESS", "message": "删除成功"})
+}
+
+// getSimDevicePersonality 按命名空间/设备查找个性配置；不存在时返回 nil（调用方应回退到默认行为）
+func getSimDevicePersonality(namespace, deviceName string) *model.SimDevicePersonality {
+	var p model.SimDevicePersonality
+	if err := database.GetDB().Where("namespace = ? AND device_name = ?", namespace, deviceName).First(&p).Error; err != nil {
+		return nil
+	}
+	return &p
+}
+
+// renderPersonalityTemplate 替换模板中的 {{hostname}}、{{privilege}} 等占位符
+func renderPersonalityTemplate(tpl string, vars map[string]string) string {
+	out := tpl
+	for k, v := range vars {
+		out = strings.ReplaceAll(out, "{{"+k+"}}", v)
+	}
+	return out
+}
+
+// renderUnknownCommandOutput 按思科/华为风格拼出"未识别命令"的错误回显：
+// 回显输入命令（各词以单个空格重新连接），在首个未匹配的词下方标出插入符，随后附上该个性固定的提示文本
+func renderUnknownCommandOutput(p *model.SimDevicePersonality, input string, tokenIndex int) string {
+	lineEnding := p.LineEnding
+	if lineEnding == "" {
+		lineEnding = "\n"
+	}
+	tokens := strings.Fields(input)
+	if len(tokens) == 0 {
+		return p.UnknownCommandTemplate
+	}
+	if tokenIndex < 0 {
+		tokenIndex = 0
+	}
+	if tokenIndex >= len(tokens) {
+		tokenIndex = len(tokens) - 1
+	}
+	pos := 0
+	for i := 0; i < tokenIndex; i++ {
+		pos += len([]rune(tokens[i])) + 1
+	}
+	caretLine := strings.Repeat(" ", pos) + "^"
+	return strings.Join(tokens, " ") + lineEnding + caretLine + lineEnding + p.UnknownCommandTemplate
+}
+
+// applyPersonalityPaging 对超过 paging_lines 的回显按行截断，并在截断处附上 --More-- 风格提示
+func applyPersonalityPaging(p *model.SimDevicePersonality, output string) (paged string, truncated bool) {
+	if p.PagingLines <= 0 {
+		return output, false
+	}
+	lineEnding := p.LineEnding
+	if lineEnding == "" {
+		lineEnding = "\n"
+	}
+	lines := strings.Split(output, lineEnding)
+	if len(lines) <= p.PagingLines {
+		return output, false
+	}
+	morePrompt := p.MorePrompt
+	if morePrompt == "" {
+		morePrompt = "--More--"
+	}
+	return strings.Join(lines[:p.PagingLines], lineEnding) + lineEnding + morePrompt, true
+}
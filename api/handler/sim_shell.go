@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sshcollectorpro/sshcollectorpro/internal/model"
+	"github.com/sshcollectorpro/sshcollectorpro/internal/simtrie"
+	"github.com/sshcollectorpro/sshcollectorpro/pkg/logger"
+)
+
+var simShellUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// simShellConnectMessage 是客户端建立连接后发送的第一条消息，用于选定模拟范围
+type simShellConnectMessage struct {
+	Namespace  string `json:"namespace"`
+	DeviceName string `json:"device_name"`
+}
+
+// SimDeviceShellHandler 处理 /api/v1/sim-device-cmds/shell 的交互式终端连接，
+// 桥接现有的 HTTP 模拟接口与 xterm.js 风格的浏览器终端/Expect 脚本
+type SimDeviceShellHandler struct{}
+
+func NewSimDeviceShellHandler() *SimDeviceShellHandler { return &SimDeviceShellHandler{} }
+
+// HandleSimDeviceShell 升级为 WebSocket 连接，按行编辑语义驱动模拟终端：
+// 回显按键，处理退格/TAB/"?"，回车时复用 MatchSimDeviceCmd 背后的 trie 匹配器
+func (h *SimDeviceShellHandler) HandleSimDeviceShell(c *gin.Context) {
+	conn, err := simShellUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logger.Error("Upgrade sim device shell failed", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	_, raw, err := conn.ReadMessage()
+	if err != nil {
+		return
+	}
+	var connect simShellConnectMessage
+	if err := json.Unmarshal(raw, &connect); err != nil {
+		_ = conn.WriteMessage(websocket.TextMessage, []byte("invalid connect message: "+err.Error()))
+		return
+	}
+	ns := strings.TrimSpace(connect.Namespace)
+	dev := strings.TrimSpace(connect.DeviceName)
+	if ns == "" || dev == "" {
+		_ = conn.WriteMessage(websocket.TextMessage, []byte("namespace and device_name are required"))
+		return
+	}
+
+	session := newSimShellSession(ns, dev)
+	session.writePrompt(conn)
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		session.handleInput(conn, string(msg))
+	}
+}
+
+// simShellSession 维护单个连接的行编辑状态（当前未提交的输入行）
+type simShellSession struct {
+	namespace  string
+	deviceName string
+	line       []rune
+}
+
+func newSimShellSession(namespace, deviceName string) *simShellSession {
+	return &simShellSession{namespace: namespace, deviceName: deviceName}
+}
+
+func (s *simShellSession) personality() *model.SimDevicePersonality {
+	return getSimDevicePersonality(s.namespace, s.deviceName)
+}
+
+func (s *simShellSession) lineEnding() string {
+	if p := s.personality(); p != nil && p.LineEnding != "" {
+		return p.LineEnding
+	}
+	return "\n"
+}
+
+func (s *simShellSession) promptText() string {
+	p := s.personality()
+	if p == nil || p.PromptTemplate == "" {
+		return s.deviceName + "> "
+	}
+	return renderPersonalityTemplate(p.PromptTemplate, map[string]string{"hostname": s.deviceName, "privilege": "1"})
+}
+
+func (s *simShellSession) writePrompt(conn *websocket.Conn) {
+	_ = conn.WriteMessage(websocket.TextMessage, []byte(s.lineEnding()+s.promptText()))
+}
+
+func (s *simShellSession) getTrie() (*simtrie.Trie, error) {
+	return getSimTrie(s.namespace, s.deviceName)
+}
+
+// handleInput 解释一段按键数据：普通字符回显入行，退格/TAB/"?"/回车触发相应动作
+func (s *simShellSession) handleInput(conn *websocket.Conn, data string) {
+	for _, r := range data {
+		switch r {
+		case '\r', '\n':
+			s.submit(conn)
+		case '\b', 127: // 退格 / DEL
+			if len(s.line) > 0 {
+				s.line = s.line[:len(s.line)-1]
+				_ = conn.WriteMessage(websocket.TextMessage, []byte("\b \b"))
+			}
+		case '\t':
+			s.complete(conn)
+		case '?':
+			s.help(conn)
+		default:
+			s.line = append(s.line, r)
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(string(r)))
+		}
+	}
+}
+
+// help 实现 "?" 键：展示当前位置上所有合法的下一个词，随后重新打印提示符与当前行
+func (s *simShellSession) help(conn *websocket.Conn) {
+	tr, err := s.getTrie()
+	if err != nil {
+		return
+	}
+	candidates := tr.Help(string(s.line))
+	_ = conn.WriteMessage(websocket.TextMessage, []byte(s.lineEnding()+strings.Join(candidates, "  ")+s.lineEnding()+s.promptText()+string(s.line)))
+}
+
+// complete 实现 TAB 键：若候选唯一则补全当前词，否则不做任何改动
+func (s *simShellSession) complete(conn *websocket.Conn) {
+	tr, err := s.getTrie()
+	if err != nil {
+		return
+	}
+	_, candidates := tr.CompletePrefix(string(s.line))
+	if len(candidates) != 1 {
+		return
+	}
+	trailingSpace := strings.HasSuffix(string(s.line), " ")
+	tokens := strings.Fields(string(s.line))
+	switch {
+	case len(tokens) == 0:
+		tokens = []string{candidates[0]}
+	case trailingSpace:
+		tokens = append(tokens, candidates[0])
+	default:
+		tokens[len(tokens)-1] = candidates[0]
+	}
+	s.line = []rune(strings.Join(tokens, " ") + " ")
+	_ = conn.WriteMessage(websocket.TextMessage, []byte(s.lineEnding()+s.promptText()+string(s.line)))
+}
+
+// submit 实现回车键：通过 trie 匹配器派发当前行，流式回写匹配结果与下一个提示符
+func (s *simShellSession) submit(conn *websocket.Conn) {
+	input := strings.TrimSpace(string(s.line))
+	s.line = nil
+	if input == "" {
+		s.writePrompt(conn)
+		return
+	}
+
+	tr, err := s.getTrie()
+	if err != nil {
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(s.lineEnding()+"% error: "+err.Error()+s.lineEnding()+s.promptText()))
+		return
+	}
+	p := s.personality()
+	result := tr.Match(input)
+
+	var output string
+	switch result.Type {
+	case simtrie.MatchExact, simtrie.MatchSingle:
+		output = result.Entry.Output
+		if p != nil {
+			output, _ = applyPersonalityPaging(p, output)
+		}
+	case simtrie.MatchAmbig, simtrie.MatchMulti:
+		lines := []string{"which command do you mean?"}
+		for _, cand := range result.Candidates {
+			lines = append(lines, " -- "+cand)
+		}
+		output = strings.Join(lines, s.lineEnding())
+	default:
+		output = "unspport command"
+		if p != nil && p.UnknownCommandTemplate != "" {
+			output = renderUnknownCommandOutput(p, input, result.TokenIndex)
+		}
+	}
+	_ = conn.WriteMessage(websocket.TextMessage, []byte(s.lineEnding()+output+s.lineEnding()+s.promptText()))
+}
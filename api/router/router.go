@@ -0,0 +1,21 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sshcollectorpro/sshcollectorpro/api/handler"
+)
+
+// SetupRouter 构建应用的 gin 引擎：先直接挂载模拟设备相关的基础路由，
+// 再调用 ExtraRoutesFunc（如果已通过 RegisterExtraRoutes 注入）挂载扩展路由，
+// 使商业版本或外部模块可以通过再次调用 RegisterSimDeviceShellRoute 覆盖默认实现。
+func SetupRouter() *gin.Engine {
+	r := gin.New()
+
+	shellHandler := handler.NewSimDeviceShellHandler()
+	RegisterSimDeviceShellRoute(r, shellHandler.HandleSimDeviceShell)
+
+	if ExtraRoutesFunc != nil {
+		ExtraRoutesFunc(r)
+	}
+	return r
+}
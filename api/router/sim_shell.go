@@ -0,0 +1,19 @@
+package router
+
+import "github.com/gin-gonic/gin"
+
+// RegisterSimDeviceShellRoute 注册模拟设备交互式终端的 WebSocket 路由（默认路径
+// /api/v1/sim-device-cmds/shell）。商业版本或外部模块可在 RegisterExtraRoutes
+// 注入的函数中对同一引擎再次调用本函数，覆盖此默认实现：路由只挂载一次，
+// 再次调用仅替换实际生效的处理函数。
+func RegisterSimDeviceShellRoute(r *gin.Engine, handle gin.HandlerFunc) {
+	_, registered := simDeviceShellHandles[r]
+	simDeviceShellHandles[r] = handle
+	if registered {
+		return
+	}
+	r.GET("/api/v1/sim-device-cmds/shell", func(c *gin.Context) { simDeviceShellHandles[r](c) })
+}
+
+// simDeviceShellHandles 记录每个引擎当前生效的终端处理函数
+var simDeviceShellHandles = map[*gin.Engine]gin.HandlerFunc{}
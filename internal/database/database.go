@@ -0,0 +1,37 @@
+package database
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var db *gorm.DB
+
+// GetDB 返回全局 GORM 连接
+func GetDB() *gorm.DB { return db }
+
+// SetDB 注入全局 GORM 连接，供启动阶段初始化调用
+func SetDB(d *gorm.DB) { db = d }
+
+// WithRetry 在遇到 SQLite Busy/Locked 等可重试错误时，按固定间隔重试
+func WithRetry(fn func(d *gorm.DB) error, attempts int, interval time.Duration) error {
+	var err error
+	for i := 0; i < attempts; i++ {
+		err = fn(GetDB())
+		if err == nil {
+			return nil
+		}
+		if !isRetryable(err) {
+			return err
+		}
+		time.Sleep(interval)
+	}
+	return err
+}
+
+func isRetryable(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "busy")
+}
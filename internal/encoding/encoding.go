@@ -0,0 +1,56 @@
+// Package encoding 提供类 iconv 的字符集转换能力，
+// 用于将华为/H3C 等设备控制台抓取的 GBK/GB18030 等非 UTF-8 回显转码为 UTF-8。
+package encoding
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/transform"
+)
+
+// ToUTF8 将 name 指定字符集的字节流转换为 UTF-8。
+// name 为空或 "utf-8"/"utf8" 时视为已是 UTF-8，原样返回。
+func ToUTF8(name string, data []byte) ([]byte, error) {
+	enc, err := lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if enc == nil {
+		return data, nil
+	}
+	out, _, err := transform.Bytes(enc.NewDecoder(), data)
+	if err != nil {
+		return nil, fmt.Errorf("转换字符集 %s 失败: %w", name, err)
+	}
+	return out, nil
+}
+
+// Supported 返回当前支持的字符集名称，供参数校验与文档展示使用
+func Supported() []string {
+	return []string{"utf-8", "gbk", "gb18030", "big5", "iso-8859-1", "shift_jis"}
+}
+
+func lookup(name string) (encoding.Encoding, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "utf-8", "utf8":
+		return nil, nil
+	case "gbk":
+		return simplifiedchinese.GBK, nil
+	case "gb18030":
+		return simplifiedchinese.GB18030, nil
+	case "big5":
+		return traditionalchinese.Big5, nil
+	case "iso-8859-1", "latin1":
+		return charmap.ISO8859_1, nil
+	case "shift_jis", "shiftjis", "sjis":
+		return japanese.ShiftJIS, nil
+	default:
+		return nil, fmt.Errorf("不支持的字符集: %s", name)
+	}
+}
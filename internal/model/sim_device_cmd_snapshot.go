@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// SimDeviceCmdSnapshot 保存某命名空间（及可选设备）下命令库在某一时刻的完整快照，
+// 用于版本化管理与回滚；Blob 为 gzip 压缩后的 JSON 命令列表
+type SimDeviceCmdSnapshot struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	Namespace  string `json:"namespace" gorm:"size:128;index:idx_sim_device_cmd_snapshot_scope"`
+	DeviceName string `json:"device_name" gorm:"size:128;index:idx_sim_device_cmd_snapshot_scope"`
+	// Revision 在同一 (namespace, device_name) 范围内单调递增
+	Revision int    `json:"revision" gorm:"index:idx_sim_device_cmd_snapshot_scope"`
+	Author   string `json:"author" gorm:"size:128"`
+	Message  string `json:"message" gorm:"size:512"`
+	// Blob 为 gzip 压缩的命令列表 JSON，不直接对外返回
+	Blob      []byte    `json:"-" gorm:"type:blob"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (SimDeviceCmdSnapshot) TableName() string { return "sim_device_cmd_snapshots" }
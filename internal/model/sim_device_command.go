@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// SimDeviceCommand 模拟设备命令库：按命名空间与设备保存命令及其预设回显
+type SimDeviceCommand struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	Namespace  string `json:"namespace" gorm:"size:128;index:idx_sim_device_cmd_scope"`
+	DeviceName string `json:"device_name" gorm:"size:128;index:idx_sim_device_cmd_scope"`
+	Command    string `json:"command" gorm:"size:512"`
+	Output     string `json:"output" gorm:"type:text"`
+	// OutputRaw 保留导入时的原始字节（转码前），便于溯源排查乱码问题；不参与匹配
+	OutputRaw []byte    `json:"output_raw,omitempty" gorm:"type:blob"`
+	Enabled   bool      `json:"enabled" gorm:"default:true"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (SimDeviceCommand) TableName() string { return "sim_device_commands" }
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// SimDevicePersonality 描述某个命名空间/设备的"设备个性"：登录提示符、分页行为与
+// 未识别命令时的错误提示风格，用于让模拟回显更贴近真实厂商设备（Cisco/Huawei 等）
+type SimDevicePersonality struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	Namespace  string `json:"namespace" gorm:"size:128;uniqueIndex:idx_sim_device_personality_scope"`
+	DeviceName string `json:"device_name" gorm:"size:128;uniqueIndex:idx_sim_device_personality_scope"`
+	Vendor     string `json:"vendor" gorm:"size:64"`
+	// PromptTemplate 支持 {{hostname}}、{{privilege}} 等占位符，如 "{{hostname}}#"、"<{{hostname}}>"
+	PromptTemplate string `json:"prompt_template" gorm:"size:128"`
+	// MorePrompt 分页提示符，如 "--More--"
+	MorePrompt string `json:"more_prompt" gorm:"size:64"`
+	// UnknownCommandTemplate 未识别命令时的固定提示文本，如 "% Invalid input detected at '^' marker."
+	UnknownCommandTemplate string    `json:"unknown_command_template" gorm:"size:256"`
+	PagingLines            int       `json:"paging_lines" gorm:"default:24"`
+	LineEnding             string    `json:"line_ending" gorm:"size:8"`
+	CreatedAt              time.Time `json:"created_at"`
+	UpdatedAt              time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (SimDevicePersonality) TableName() string { return "sim_device_personalities" }
@@ -0,0 +1,246 @@
+// Package simtrie 实现一个面向 CLI 风格多词命令的前缀字典树（trie），
+// 用于支持唯一前缀展开（如 "sh ru" -> "show running-config"）、
+// 歧义词提示、"?" 帮助以及 TAB 补全等思科/华为风格的终端交互语义。
+package simtrie
+
+import (
+	"sort"
+	"strings"
+)
+
+// Entry 是构建 trie 的一条命令记录
+type Entry struct {
+	ID      uint
+	Command string
+	Output  string
+}
+
+// MatchType 描述一次匹配的结果类型
+type MatchType string
+
+const (
+	MatchExact  MatchType = "exact"
+	MatchSingle MatchType = "partial_single"
+	MatchMulti  MatchType = "partial_multi"
+	MatchAmbig  MatchType = "ambiguous_token"
+	MatchNone   MatchType = "none"
+)
+
+// MatchResult 是一次匹配的返回值
+type MatchResult struct {
+	Type MatchType
+	// Entry 仅在 MatchExact / MatchSingle 时有效
+	Entry *Entry
+	// TokenIndex 记录匹配失败或产生歧义时所在的词位置（从 0 开始）
+	TokenIndex int
+	// Candidates 在 MatchMulti 时为候选完整命令，在 MatchAmbig 时为该位置的候选词（保留原始大小写）
+	Candidates []string
+}
+
+type trieNode struct {
+	children map[string]*trieNode // key: 词的小写形式
+	original string               // 该词首次出现时的原始大小写
+	terminal bool
+	entry    Entry
+}
+
+func newNode() *trieNode {
+	return &trieNode{children: map[string]*trieNode{}}
+}
+
+// Trie 是针对某一 (namespace, device) 范围构建的不可变命令前缀树。
+// 构建后的 Trie 只读，并发读取是安全的；重建时应整体替换指针而非原地修改。
+type Trie struct {
+	root *trieNode
+}
+
+// Build 从命令记录集合构建 trie；多次以相同输入构建会得到等价结果（幂等）。
+func Build(entries []Entry) *Trie {
+	root := newNode()
+	for _, e := range entries {
+		tokens := strings.Fields(e.Command)
+		cur := root
+		for _, tok := range tokens {
+			key := strings.ToLower(tok)
+			child, ok := cur.children[key]
+			if !ok {
+				child = newNode()
+				child.original = tok
+				cur.children[key] = child
+			}
+			cur = child
+		}
+		cur.terminal = true
+		cur.entry = e
+	}
+	return &Trie{root: root}
+}
+
+// uniqueChild 返回在 cur 下，小写前缀为 key 的唯一子节点；不存在或存在多个时返回 nil
+func uniqueChild(cur *trieNode, key string) *trieNode {
+	// 精确匹配优先：若该词恰好等于某个子节点的完整词，直接采用，
+	// 不因其恰好也是其他兄弟词的前缀而判定为歧义
+	if exact, ok := cur.children[key]; ok {
+		return exact
+	}
+	var matched *trieNode
+	count := 0
+	for k, child := range cur.children {
+		if strings.HasPrefix(k, key) {
+			matched = child
+			count++
+		}
+		if count > 1 {
+			return nil
+		}
+	}
+	if count == 1 {
+		return matched
+	}
+	return nil
+}
+
+func collectLeaves(n *trieNode) []*trieNode {
+	var leaves []*trieNode
+	if n.terminal {
+		leaves = append(leaves, n)
+	}
+	for _, child := range n.children {
+		leaves = append(leaves, collectLeaves(child)...)
+	}
+	return leaves
+}
+
+// Match 按词逐位匹配输入，支持每个词的唯一前缀展开。
+// 某一位置若存在多个候选前缀，立即返回该位置的候选词（而非整条命令的候选）。
+func (t *Trie) Match(input string) MatchResult {
+	tokens := strings.Fields(input)
+	if len(tokens) == 0 {
+		return MatchResult{Type: MatchNone}
+	}
+	cur := t.root
+	for i, tok := range tokens {
+		key := strings.ToLower(tok)
+		// 精确匹配优先：该词恰好等于某个子节点的完整词时直接采用，
+		// 不因其恰好也是其他兄弟词的前缀而判定为歧义（例如 "show interface brief"
+		// 的 "interface" 不应因 "interfaces" 的存在而被当成歧义词）
+		if exact, ok := cur.children[key]; ok {
+			cur = exact
+			continue
+		}
+		var candidates []*trieNode
+		for k, child := range cur.children {
+			if strings.HasPrefix(k, key) {
+				candidates = append(candidates, child)
+			}
+		}
+		switch len(candidates) {
+		case 0:
+			return MatchResult{Type: MatchNone, TokenIndex: i}
+		case 1:
+			cur = candidates[0]
+		default:
+			var names []string
+			for _, c := range candidates {
+				names = append(names, c.original)
+			}
+			sort.Strings(names)
+			return MatchResult{Type: MatchAmbig, TokenIndex: i, Candidates: names}
+		}
+	}
+
+	if cur.terminal {
+		entry := cur.entry
+		return MatchResult{Type: MatchExact, Entry: &entry}
+	}
+
+	leaves := collectLeaves(cur)
+	if len(leaves) == 1 {
+		entry := leaves[0].entry
+		return MatchResult{Type: MatchSingle, Entry: &entry}
+	}
+	var names []string
+	for _, l := range leaves {
+		names = append(names, l.entry.Command)
+	}
+	sort.Strings(names)
+	return MatchResult{Type: MatchMulti, Candidates: names}
+}
+
+// Help 实现 "?" 语义：给定当前已输入的内容（可能以空格结尾），
+// 返回该位置上所有合法的下一个词（保留原始大小写，按字典序排列）。
+// 输入路径本身无法唯一解析时返回空列表。
+func (t *Trie) Help(input string) []string {
+	trailingSpace := strings.HasSuffix(input, " ")
+	tokens := strings.Fields(input)
+	var partial string
+	if !trailingSpace && len(tokens) > 0 {
+		partial = tokens[len(tokens)-1]
+		tokens = tokens[:len(tokens)-1]
+	}
+	cur := t.root
+	for _, tok := range tokens {
+		child := uniqueChild(cur, strings.ToLower(tok))
+		if child == nil {
+			return nil
+		}
+		cur = child
+	}
+	key := strings.ToLower(partial)
+	var names []string
+	for k, child := range cur.children {
+		if strings.HasPrefix(k, key) {
+			names = append(names, child.original)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CompletePrefix 实现 TAB 补全：返回当前正在输入的词在该位置下所有候选词的最长公共前缀，
+// 以及候选词列表本身（保留原始大小写）。无法解析或无候选时原样返回当前正在输入的词与空候选。
+func (t *Trie) CompletePrefix(input string) (string, []string) {
+	trailingSpace := strings.HasSuffix(input, " ")
+	tokens := strings.Fields(input)
+	var partial string
+	if !trailingSpace && len(tokens) > 0 {
+		partial = tokens[len(tokens)-1]
+		tokens = tokens[:len(tokens)-1]
+	}
+	cur := t.root
+	for _, tok := range tokens {
+		child := uniqueChild(cur, strings.ToLower(tok))
+		if child == nil {
+			return partial, nil
+		}
+		cur = child
+	}
+	key := strings.ToLower(partial)
+	var names []string
+	for k, child := range cur.children {
+		if strings.HasPrefix(k, key) {
+			names = append(names, child.original)
+		}
+	}
+	if len(names) == 0 {
+		return partial, nil
+	}
+	sort.Strings(names)
+	return longestCommonPrefix(names), names
+}
+
+func longestCommonPrefix(words []string) string {
+	if len(words) == 0 {
+		return ""
+	}
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(strings.ToLower(w), strings.ToLower(prefix)) {
+			prefix = prefix[:len(prefix)-1]
+			if prefix == "" {
+				return ""
+			}
+		}
+	}
+	return prefix
+}
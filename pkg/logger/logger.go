@@ -0,0 +1,23 @@
+package logger
+
+import "log"
+
+// Error 记录错误级别日志，kv 为交替的 key/value 对
+func Error(msg string, kv ...interface{}) {
+	logWithLevel("ERROR", msg, kv...)
+}
+
+// Warn 记录警告级别日志
+func Warn(msg string, kv ...interface{}) {
+	logWithLevel("WARN", msg, kv...)
+}
+
+// Info 记录信息级别日志
+func Info(msg string, kv ...interface{}) {
+	logWithLevel("INFO", msg, kv...)
+}
+
+func logWithLevel(level string, msg string, kv ...interface{}) {
+	args := append([]interface{}{"[" + level + "] " + msg}, kv...)
+	log.Println(args...)
+}